@@ -1,56 +1,333 @@
 package bigippg
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/f5devcentral/go-bigip"
 )
 
+// defaultTokenTimeout mirrors the iControl REST token lifetime BIG-IP
+// enforces today (1200 seconds).
+const defaultTokenTimeout = 1200 * time.Second
+
+// tokenRefreshMargin is how far ahead of expiry the background refresh
+// goroutine re-authenticates, so a slow request never races a dead token.
+const tokenRefreshMargin = 60 * time.Second
+
+// Defaults for the validateConnection retry/backoff loop.
+const (
+	defaultRetryMax     = 3
+	defaultRetryWaitMin = 1 * time.Second
+	defaultRetryWaitMax = 30 * time.Second
+)
+
+// validLoginReferences are the auth providers BIG-IP accepts for
+// token-based login.
+var validLoginReferences = map[string]bool{
+	"tmos":             true,
+	"local":            true,
+	"RADIUS":           true,
+	"TACACS+":          true,
+	"LDAP":             true,
+	"Active Directory": true,
+}
+
 type Config struct {
 	Address        string
 	Port           string
 	Username       string
 	Password       string
 	LoginReference string `json:"loginProviderName"`
-	ConfigOptions  *bigip.ConfigOptions
+	// Token, when set, is used as a pre-obtained bearer token instead of
+	// exchanging Username/Password for one.
+	Token string
+	// TokenTimeout overrides how long a token is trusted to live before the
+	// background refresh goroutine re-authenticates. Defaults to 1200s.
+	TokenTimeout time.Duration
+	// TrustedCertPath is a PEM file of CA certificates to trust in place of
+	// the system pool, for devices fronted by a private CA.
+	TrustedCertPath string
+	// HTTPTransport, when set, replaces the session's transport entirely,
+	// for callers that need keep-alive tuning, connection pooling, mTLS
+	// client certs, or a proxy in front of the BIG-IP.
+	HTTPTransport *http.Transport
+	// RetryMax, RetryWaitMin and RetryWaitMax configure the exponential
+	// backoff validateConnection uses against retriable errors (network
+	// failures, 5xx, a restarting Configuration Utility). They default to
+	// 3 retries between 1s and 30s.
+	RetryMax     int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+	ConfigOptions *bigip.ConfigOptions
+
+	// trustedCertPool is loaded from TrustedCertPath by configureTrustedCert
+	// and applied to whichever transport the session ends up using.
+	trustedCertPool *x509.CertPool
+
+	// tokenMu guards client.Token on sessions this Config manages, since the
+	// background refresh goroutine and any 401-triggered refresh write it
+	// concurrently with requests reading it through clientToken.
+	//
+	// This only protects readers/writers in this package. go-bigip's own
+	// methods (e.g. SelfIPs(), used by validateConnection) read client.Token
+	// directly with no lock of their own, so a refresh landing while one of
+	// those calls is in flight is still a data race in the strict sense -
+	// there's no exported hook in go-bigip to synchronize against. In
+	// practice the window is narrow (a single header read at request start)
+	// and a torn read means one request goes out with a stale or half-written
+	// token and gets a 401. Such a go-bigip call is not retried here: only
+	// doJSONRequest refreshes the token and retries on a 401, and its own
+	// token reads already go through clientToken. Wrapping every go-bigip call through
+	// clientToken would require forking it, which is out of scope here.
+	tokenMu sync.Mutex
 }
 
-func (c *Config) Client() (*bigip.BigIP, error) {
+// clientConfigs associates a live *bigip.BigIP with the Config that created
+// it, so code that only has the client (e.g. resource CRUD functions, which
+// receive it as Terraform's meta interface{}) can still trigger a token
+// refresh after observing a 401.
+var (
+	clientConfigsMu sync.RWMutex
+	clientConfigs   = map[*bigip.BigIP]*Config{}
+)
 
-	if c.Address != "" && c.Username != "" && c.Password != "" {
-		log.Println("[INFO] Initializing BigIP connection")
-		var client *bigip.BigIP
-		var err error
-		if c.LoginReference != "" {
-			client, err = bigip.NewTokenSession(c.Address, c.Port, c.Username, c.Password, c.LoginReference, c.ConfigOptions)
-			if err != nil {
-				log.Printf("[ERROR] Error creating New Token Session %s ", err)
-				return nil, err
-			}
+func registerClientConfig(client *bigip.BigIP, c *Config) {
+	clientConfigsMu.Lock()
+	defer clientConfigsMu.Unlock()
+	clientConfigs[client] = c
+}
+
+// configForClient looks up the Config that produced client, if any.
+func configForClient(client *bigip.BigIP) *Config {
+	clientConfigsMu.RLock()
+	defer clientConfigsMu.RUnlock()
+	return clientConfigs[client]
+}
+
+// clientToken reads client.Token under c's lock, so it never races the
+// background refresh goroutine's or a 401 handler's write to the same field.
+func clientToken(client *bigip.BigIP, c *Config) string {
+	if c == nil {
+		return client.Token
+	}
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return client.Token
+}
 
-		} else {
-			client = bigip.NewSession(c.Address, c.Port, c.Username, c.Password, c.ConfigOptions)
+// Validate checks the auth-provider configuration before a connection is
+// ever attempted, so provider.ConfigureFunc can fail fast with a helpful
+// diagnostic instead of surfacing an opaque BIG-IP auth error later.
+func (c *Config) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("BigIP provider requires an address")
+	}
+	if c.Token == "" && (c.Username == "" || c.Password == "") {
+		return fmt.Errorf("BigIP provider requires either a token or a username and password")
+	}
+	if c.LoginReference != "" && !validLoginReferences[c.LoginReference] {
+		return fmt.Errorf("%q is not a supported login_reference, must be one of tmos, local, RADIUS, TACACS+, LDAP, Active Directory", c.LoginReference)
+	}
+	return nil
+}
+
+func (c *Config) Client() (*bigip.BigIP, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.TrustedCertPath != "" {
+		if err := c.configureTrustedCert(); err != nil {
+			return nil, err
 		}
-		err = c.validateConnection(client)
-		if err == nil {
-			return client, nil
+	}
+
+	log.Println("[INFO] Initializing BigIP connection")
+	var client *bigip.BigIP
+	var err error
+	switch {
+	case c.Token != "":
+		client = bigip.NewSession(c.Address, c.Port, c.Username, c.Password, c.ConfigOptions)
+		client.Token = c.Token
+	case c.LoginReference != "":
+		client, err = bigip.NewTokenSession(c.Address, c.Port, c.Username, c.Password, c.LoginReference, c.ConfigOptions)
+		if err != nil {
+			log.Printf("[ERROR] Error creating New Token Session %s ", err)
+			return nil, err
 		}
+	default:
+		client = bigip.NewSession(c.Address, c.Port, c.Username, c.Password, c.ConfigOptions)
+	}
+
+	if c.HTTPTransport != nil {
+		c.applyTrustedCertTo(c.HTTPTransport)
+		client.Transport = c.HTTPTransport
+	}
+
+	registerClientConfig(client, c)
+
+	if err = c.validateConnection(client); err != nil {
 		return nil, err
 	}
-	return nil, fmt.Errorf("BigIP provider requires address, username and password")
+
+	if c.LoginReference != "" {
+		c.startTokenRefresh(client)
+	}
+
+	return client, nil
 }
 
-func (c *Config) validateConnection(client *bigip.BigIP) error {
-	t, err := client.SelfIPs()
+// configureTrustedCert loads TrustedCertPath and applies it to ConfigOptions
+// so the BIG-IP's certificate can be verified against a private CA. The
+// same pool is kept on c so it can also be applied to an HTTPTransport
+// override (see applyTrustedCertTo), instead of being silently dropped.
+func (c *Config) configureTrustedCert() error {
+	pem, err := ioutil.ReadFile(c.TrustedCertPath)
 	if err != nil {
-		log.Printf("[ERROR] Connection to BigIP device could not have been validated: %v ", err)
-		return err
+		return fmt.Errorf("unable to read trusted_cert_path %q: %s", c.TrustedCertPath, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates found in trusted_cert_path %q", c.TrustedCertPath)
 	}
+	c.trustedCertPool = pool
 
-	if t == nil {
-		log.Printf("[WARN] Could not validate connection to BigIP")
-		return nil
+	if c.ConfigOptions == nil {
+		c.ConfigOptions = &bigip.ConfigOptions{}
 	}
+	if c.ConfigOptions.TLSConfig == nil {
+		c.ConfigOptions.TLSConfig = &tls.Config{}
+	}
+	c.ConfigOptions.TLSConfig.RootCAs = pool
+	return nil
+}
+
+// applyTrustedCertTo sets TrustedCertPath's CA pool as t's RootCAs, so an
+// HTTPTransport override doesn't silently discard trust configured via
+// TrustedCertPath. A transport that already sets RootCAs keeps its own pool
+// unchanged; the two pools are not merged.
+func (c *Config) applyTrustedCertTo(t *http.Transport) {
+	if c.trustedCertPool == nil {
+		return
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	if t.TLSClientConfig.RootCAs == nil {
+		t.TLSClientConfig.RootCAs = c.trustedCertPool
+	}
+}
+
+// startTokenRefresh launches a background goroutine that re-authenticates
+// the session before its token reaches its lifetime, swapping the new
+// token into client so in-flight resources keep using the same *bigip.BigIP.
+// RefreshToken (below) lets callers force the same re-auth immediately;
+// doJSONRequest does exactly that when a request comes back 401.
+func (c *Config) startTokenRefresh(client *bigip.BigIP) {
+	timeout := c.TokenTimeout
+	if timeout <= 0 {
+		timeout = defaultTokenTimeout
+	}
+	interval := timeout - tokenRefreshMargin
+	if interval <= 0 {
+		interval = timeout
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := c.RefreshToken(client); err != nil {
+				log.Printf("[ERROR] Token refresh failed, will retry next interval: %s", err)
+			}
+		}
+	}()
+}
+
+// RefreshToken re-authenticates against LoginReference and swaps the new
+// token into client under c's lock, so it's safe to call concurrently from
+// the background refresh goroutine and from a caller that has just observed
+// a 401 from client. See the tokenMu field comment for the remaining race
+// against go-bigip's own unlocked reads of client.Token.
+func (c *Config) RefreshToken(client *bigip.BigIP) error {
+	newClient, err := bigip.NewTokenSession(c.Address, c.Port, c.Username, c.Password, c.LoginReference, c.ConfigOptions)
+	if err != nil {
+		return err
+	}
+	c.tokenMu.Lock()
+	client.Token = newClient.Token
+	c.tokenMu.Unlock()
+	log.Println("[INFO] Refreshed BigIP auth token")
 	return nil
 }
+
+// validateConnection retries a SelfIPs() probe with exponential backoff,
+// since BIG-IP devices that are rebooting, mid-failover, or behind a slow
+// VPN can fail the first few calls with a retriable error. Auth errors
+// (401/403) are terminal and returned immediately.
+func (c *Config) validateConnection(client *bigip.BigIP) error {
+	retryMax := c.RetryMax
+	if retryMax <= 0 {
+		retryMax = defaultRetryMax
+	}
+	waitMin := c.RetryWaitMin
+	if waitMin <= 0 {
+		waitMin = defaultRetryWaitMin
+	}
+	waitMax := c.RetryWaitMax
+	if waitMax <= 0 {
+		waitMax = defaultRetryWaitMax
+	}
+
+	wait := waitMin
+	var lastErr error
+	for attempt := 1; attempt <= retryMax+1; attempt++ {
+		t, err := client.SelfIPs()
+		if err == nil {
+			if t == nil {
+				log.Printf("[WARN] Could not validate connection to BigIP")
+			}
+			return nil
+		}
+
+		if isTerminalConnectionError(err) {
+			log.Printf("[ERROR] Connection to BigIP device could not have been validated: %v ", err)
+			return err
+		}
+
+		lastErr = err
+		if attempt > retryMax {
+			break
+		}
+		log.Printf("[WARN] Attempt %d/%d to validate connection to BigIP failed, retrying in %s: %v", attempt, retryMax+1, wait, err)
+		time.Sleep(wait)
+		wait *= 2
+		if wait > waitMax {
+			wait = waitMax
+		}
+	}
+
+	log.Printf("[ERROR] Connection to BigIP device could not have been validated after %d attempts: %v ", retryMax+1, lastErr)
+	return lastErr
+}
+
+// isTerminalConnectionError reports whether err represents a BIG-IP auth
+// failure that retrying won't fix, as opposed to a transient network/5xx
+// error or a Configuration Utility that is still restarting.
+func isTerminalConnectionError(err error) bool {
+	msg := err.Error()
+	for _, terminal := range []string{"401", "403", "Unauthorized", "Forbidden"} {
+		if strings.Contains(msg, terminal) {
+			return true
+		}
+	}
+	return false
+}
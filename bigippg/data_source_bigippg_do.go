@@ -0,0 +1,45 @@
+package bigippg
+
+import (
+	"crypto/md5"
+	"fmt"
+
+	"github.com/f5devcentral/go-bigip"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceBigippgDo() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceBigippgDoRead,
+
+		Schema: map[string]*schema.Schema{
+			"tenant_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "Common",
+				Description: "The tenant name, for display/bookkeeping purposes; the device's whole Declarative Onboarding state is read regardless of this value.",
+			},
+			"result": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The current Declarative Onboarding state as reported by BIG-IP.",
+			},
+		},
+	}
+}
+
+func dataSourceBigippgDoRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*bigip.BigIP)
+
+	body, status, err := doJSONRequest(client, "GET", doBasePath, nil)
+	if err != nil {
+		return fmt.Errorf("error reading Declarative Onboarding state: %s", err)
+	}
+	if status >= 300 {
+		return fmt.Errorf("error reading Declarative Onboarding state (status %d): %s", status, body)
+	}
+
+	d.SetId(fmt.Sprintf("%x", md5.Sum(body)))
+	d.Set("result", string(body))
+	return nil
+}
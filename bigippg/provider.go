@@ -0,0 +1,122 @@
+package bigippg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// Provider returns the schema.Provider for this plugin, wiring the
+// bigippg_* resources and data sources (currently just Declarative
+// Onboarding) into Terraform's resource/data source maps.
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"address": {
+				Type:        schema.TypeString,
+				Required:    true,
+				DefaultFunc: schema.EnvDefaultFunc("BIGIP_HOST", nil),
+				Description: "Address of the device to manage",
+			},
+			"port": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "443",
+				Description: "Port to connect to the BigIP",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("BIGIP_USER", nil),
+				Description: "Username to authenticate with the device",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("BIGIP_PASSWORD", nil),
+				Description: "Password to authenticate with the device",
+			},
+			"token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("BIGIP_TOKEN", nil),
+				Description: "Pre-obtained bearer token to authenticate with the device, in place of username/password",
+			},
+			"login_reference": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("BIGIP_LOGIN_REF", nil),
+				Description: "Login reference for token-based authentication against an external auth provider (tmos, local, RADIUS, TACACS+, LDAP, Active Directory)",
+			},
+			"token_timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     1200,
+				Description: "Seconds a token is trusted to live before it's refreshed in the background. Defaults to BIG-IP's 1200 second token lifetime",
+			},
+			"trusted_cert_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("BIGIP_TRUSTED_CERT_PATH", nil),
+				Description: "Path to a PEM file of CA certificates to trust in place of the system pool",
+			},
+			"retry_max": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     defaultRetryMax,
+				Description: "Number of retries validateConnection attempts against a retriable error (network failures, 5xx, a restarting Configuration Utility) before giving up",
+			},
+			"retry_wait_min": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     int(defaultRetryWaitMin / time.Second),
+				Description: "Seconds to wait before the first connection retry; doubles on each subsequent attempt up to retry_wait_max",
+			},
+			"retry_wait_max": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     int(defaultRetryWaitMax / time.Second),
+				Description: "Maximum seconds to wait between connection retries",
+			},
+		},
+
+		ResourcesMap: map[string]*schema.Resource{
+			"bigippg_do": resourceBigippgDo(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"bigippg_do": dataSourceBigippgDo(),
+		},
+
+		ConfigureFunc: providerConfigure,
+	}
+}
+
+func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+	config := Config{
+		Address:         d.Get("address").(string),
+		Port:            d.Get("port").(string),
+		Username:        d.Get("username").(string),
+		Password:        d.Get("password").(string),
+		Token:           d.Get("token").(string),
+		LoginReference:  d.Get("login_reference").(string),
+		TokenTimeout:    time.Duration(d.Get("token_timeout").(int)) * time.Second,
+		TrustedCertPath: d.Get("trusted_cert_path").(string),
+		RetryMax:        d.Get("retry_max").(int),
+		RetryWaitMin:    time.Duration(d.Get("retry_wait_min").(int)) * time.Second,
+		RetryWaitMax:    time.Duration(d.Get("retry_wait_max").(int)) * time.Second,
+		// HTTPTransport has no HCL-expressible shape (it's a live
+		// *http.Transport for keep-alive tuning, mTLS client certs, etc.)
+		// so it's programmatic-only: set Config.HTTPTransport directly when
+		// embedding this provider rather than through Terraform config.
+	}
+
+	client, err := config.Client()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring BigIP provider: %s", err)
+	}
+	return client, nil
+}
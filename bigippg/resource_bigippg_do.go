@@ -0,0 +1,230 @@
+package bigippg
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/f5devcentral/go-bigip"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// doBasePath is the iControl REST endpoint Declarative Onboarding
+// declarations are POSTed to and task state is polled from.
+const doBasePath = "mgmt/shared/declarative-onboarding"
+
+const doDefaultTimeout = 20 * 60 // 20m, in seconds
+
+const doPollInterval = 5 * time.Second
+
+func resourceBigippgDo() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceBigippgDoCreate,
+		Read:   resourceBigippgDoRead,
+		Update: resourceBigippgDoUpdate,
+		Delete: resourceBigippgDoDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"config": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateDOJSON,
+				Description:  "The Declarative Onboarding JSON declaration to apply.",
+			},
+			"tenant_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "Common",
+				Description: "The tenant this declaration targets, for display/bookkeeping purposes.",
+			},
+			"timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     doDefaultTimeout,
+				Description: "Seconds to wait for the Declarative Onboarding task to reach a terminal state. Defaults to 20 minutes.",
+			},
+			"task_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The Declarative Onboarding task ID returned for the applied declaration.",
+			},
+			"result": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The raw Declarative Onboarding task result once the task reports OK; it is not set when the task ends in ERROR.",
+			},
+		},
+	}
+}
+
+// doTaskResponse models the subset of a Declarative Onboarding task
+// response this resource cares about.
+type doTaskResponse struct {
+	ID     string `json:"id"`
+	Result struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"result"`
+}
+
+func resourceBigippgDoCreate(d *schema.ResourceData, meta interface{}) error {
+	return resourceBigippgDoApply(d, meta)
+}
+
+func resourceBigippgDoUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceBigippgDoApply(d, meta)
+}
+
+// resourceBigippgDoApply POSTs the declaration and waits for its task to
+// finish. Since Declarative Onboarding is imperative, Create and Update do
+// the same thing: submit the current config.
+func resourceBigippgDoApply(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*bigip.BigIP)
+	config := d.Get("config").(string)
+
+	body, status, err := doJSONRequest(client, "POST", doBasePath, []byte(config))
+	if err != nil {
+		return fmt.Errorf("error submitting Declarative Onboarding declaration: %s", err)
+	}
+	if status >= 300 {
+		return fmt.Errorf("Declarative Onboarding declaration rejected (status %d): %s", status, body)
+	}
+
+	var submitted doTaskResponse
+	if err := json.Unmarshal(body, &submitted); err != nil {
+		return fmt.Errorf("error parsing Declarative Onboarding response: %s", err)
+	}
+
+	d.SetId(fmt.Sprintf("%x", md5.Sum([]byte(config))))
+	d.Set("task_id", submitted.ID)
+
+	return doWaitForTask(d, client, submitted.ID)
+}
+
+func doWaitForTask(d *schema.ResourceData, client *bigip.BigIP, taskID string) error {
+	timeout := time.Duration(d.Get("timeout").(int)) * time.Second
+	deadline := time.Now().Add(timeout)
+	taskPath := fmt.Sprintf("%s/task/%s", doBasePath, taskID)
+
+	for {
+		body, status, err := doJSONRequest(client, "GET", taskPath, nil)
+		if err != nil {
+			return fmt.Errorf("error polling Declarative Onboarding task %s: %s", taskID, err)
+		}
+		if status >= 300 {
+			return fmt.Errorf("error polling Declarative Onboarding task %s (status %d): %s", taskID, status, body)
+		}
+
+		var task doTaskResponse
+		if err := json.Unmarshal(body, &task); err != nil {
+			return fmt.Errorf("error parsing Declarative Onboarding task response: %s", err)
+		}
+
+		switch task.Result.Status {
+		case "OK":
+			d.Set("result", string(body))
+			return nil
+		case "ERROR":
+			return fmt.Errorf("Declarative Onboarding task %s failed: %s", taskID, task.Result.Message)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for Declarative Onboarding task %s to complete", timeout, taskID)
+		}
+		time.Sleep(doPollInterval)
+	}
+}
+
+// Read is a no-op: the declaration isn't readable back from BIG-IP in a form
+// comparable to what was submitted, so this resource trusts its last known
+// task_id/result rather than re-deriving them.
+func resourceBigippgDoRead(d *schema.ResourceData, meta interface{}) error {
+	return nil
+}
+
+// Delete is a no-op: Declarative Onboarding is imperative, there is nothing
+// to roll back on BIG-IP by removing this resource from state.
+func resourceBigippgDoDelete(d *schema.ResourceData, meta interface{}) error {
+	d.SetId("")
+	return nil
+}
+
+// doJSONRequest issues a raw iControl REST call against client's host using
+// its existing auth (token if present, otherwise basic auth), since
+// Declarative Onboarding isn't modeled in go-bigip. On a session with a
+// LoginReference, a 401 triggers one immediate token refresh and retry, in
+// case the background refresh goroutine hasn't caught up with an expired or
+// revoked token; other sessions return the 401 as is.
+func doJSONRequest(client *bigip.BigIP, method, path string, body []byte) ([]byte, int, error) {
+	cfg := configForClient(client)
+
+	respBody, status, err := doJSONRequestOnce(client, cfg, method, path, body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if status == http.StatusUnauthorized && cfg != nil && cfg.LoginReference != "" {
+		log.Printf("[WARN] Declarative Onboarding request to %s got 401, refreshing BigIP auth token and retrying", path)
+		if refreshErr := cfg.RefreshToken(client); refreshErr == nil {
+			return doJSONRequestOnce(client, cfg, method, path, body)
+		}
+	}
+
+	return respBody, status, nil
+}
+
+// doRequestURL builds the iControl REST URL for path against the same
+// host:port go-bigip's own session was configured with, since client.Host
+// alone drops the provider's port setting.
+func doRequestURL(client *bigip.BigIP, cfg *Config, path string) string {
+	host := client.Host
+	if cfg != nil && cfg.Port != "" && !strings.Contains(host, ":") {
+		host = net.JoinHostPort(host, cfg.Port)
+	}
+	return fmt.Sprintf("https://%s/%s", host, path)
+}
+
+func doJSONRequestOnce(client *bigip.BigIP, cfg *Config, method, path string, body []byte) ([]byte, int, error) {
+	httpClient := &http.Client{Transport: client.Transport}
+	url := doRequestURL(client, cfg, path)
+
+	var reader *bytes.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	} else {
+		reader = bytes.NewReader([]byte{})
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := clientToken(client, cfg); token != "" {
+		req.Header.Set("X-F5-Auth-Token", token)
+	} else {
+		req.SetBasicAuth(client.User, client.Password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	return respBody, resp.StatusCode, nil
+}
@@ -0,0 +1,30 @@
+package bigippg
+
+import (
+	"testing"
+
+	"github.com/f5devcentral/go-bigip"
+)
+
+func TestDoRequestURL(t *testing.T) {
+	cases := []struct {
+		name string
+		host string
+		cfg  *Config
+		want string
+	}{
+		{"default port, no cfg", "bigip.example.com", nil, "https://bigip.example.com/" + doBasePath},
+		{"non-443 port from cfg", "bigip.example.com", &Config{Port: "8443"}, "https://bigip.example.com:8443/" + doBasePath},
+		{"host already carries a port", "bigip.example.com:8443", &Config{Port: "443"}, "https://bigip.example.com:8443/" + doBasePath},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &bigip.BigIP{Host: tc.host}
+			got := doRequestURL(client, tc.cfg, doBasePath)
+			if got != tc.want {
+				t.Errorf("doRequestURL() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -7,22 +7,115 @@ If a copy of the MPL was not distributed with this file,You can obtain one at ht
 package bigippg
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"os"
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 )
 
+// ValidatorMode controls how a validator surfaces a failed check: Deny
+// fails plan/apply (the default), Warn turns failures into non-blocking
+// warnings, and Off skips the check entirely. This lets operators
+// migrating legacy configs with slightly-off names plan/apply with
+// warnings first, then flip to Deny once everything is clean.
+type ValidatorMode int
+
+const (
+	Deny ValidatorMode = iota
+	Warn
+	Off
+)
+
+func (m ValidatorMode) String() string {
+	switch m {
+	case Warn:
+		return "warn"
+	case Off:
+		return "off"
+	default:
+		return "deny"
+	}
+}
+
+var (
+	validatorModesMu sync.RWMutex
+	validatorModes   = map[string]ValidatorMode{}
+)
+
+// SetValidatorMode configures how the named validator (e.g. "f5_name",
+// "partition_name", "pool_member_name") reports failures. It overrides any
+// BIGIPPG_VALIDATOR_MODE_<NAME> environment variable for that validator.
+func SetValidatorMode(name string, mode ValidatorMode) {
+	validatorModesMu.Lock()
+	defer validatorModesMu.Unlock()
+	validatorModes[name] = mode
+}
+
+// validatorMode resolves the effective mode for a validator: an explicit
+// SetValidatorMode call wins, then a BIGIPPG_VALIDATOR_MODE_<NAME> env var
+// (e.g. BIGIPPG_VALIDATOR_MODE_F5_NAME=warn), then Deny.
+func validatorMode(name string) ValidatorMode {
+	validatorModesMu.RLock()
+	mode, ok := validatorModes[name]
+	validatorModesMu.RUnlock()
+	if ok {
+		return mode
+	}
+
+	switch strings.ToLower(os.Getenv("BIGIPPG_VALIDATOR_MODE_" + strings.ToUpper(name))) {
+	case "warn":
+		return Warn
+	case "off":
+		return Off
+	}
+	return Deny
+}
+
+// applyValidatorMode turns a validator's raw failures into the errors or
+// warnings schema.SchemaValidateFunc expects, per that validator's mode.
+func applyValidatorMode(name string, errs []error) (ws []string, errors []error) {
+	if len(errs) == 0 {
+		return nil, nil
+	}
+
+	switch validatorMode(name) {
+	case Off:
+		return nil, nil
+	case Warn:
+		for _, e := range errs {
+			ws = append(ws, e.Error())
+		}
+		return ws, nil
+	default:
+		return nil, errs
+	}
+}
+
+// f5NameValuePattern matches the value half of an F5 object name: a bracketed
+// IPv6 literal (e.g. [2001:db8::1]) or a plain word made up of letters,
+// numbers and [._-:], either of which may carry a trailing %<route-domain>
+// suffix (e.g. [2001:db8::1%2], 10.1.1.1%2).
+const f5NameValuePattern = `(?:\[[0-9A-Fa-f:]+(?:%\d+)?\]|[\w_\-.:]+(?:%\d+)?)`
+
+// hostPortPattern matches a pool-member endpoint: an IPv4 address, an FQDN,
+// or a bracketed IPv6 literal, any of which may carry a %<route-domain>
+// suffix, followed by :<port>.
+const hostPortPattern = `(?:\[[0-9A-Fa-f:]+(?:%\d+)?\]|[\w_\-.]+(?:%\d+)?):\d+`
+
 //Validate the incoming set only contains values from the specified set
 func validateSetValues(valid *schema.Set) schema.SchemaValidateFunc {
 	return func(value interface{}, field string) (ws []string, errors []error) {
+		var errs []error
 		if valid.Intersection(value.(*schema.Set)).Len() != value.(*schema.Set).Len() {
-			errors = append(errors, fmt.Errorf("%q can only contain %v", field, value.(*schema.Set).List()))
+			errs = append(errs, fmt.Errorf("%q can only contain %v", field, value.(*schema.Set).List()))
 		}
-		return
+		return applyValidatorMode("set_values", errs)
 	}
 }
 
@@ -33,8 +126,7 @@ func validateStringValue(values []string) schema.SchemaValidateFunc {
 				return
 			}
 		}
-		errors = append(errors, fmt.Errorf("%q must be one of %v", field, values))
-		return
+		return applyValidatorMode("string_value", []error{fmt.Errorf("%q must be one of %v", field, values)})
 	}
 }
 
@@ -58,12 +150,12 @@ func validateF5Name(value interface{}, field string) (ws []string, errors []erro
 	}
 
 	for _, v := range values {
-		match, _ := regexp.MatchString("^/[\\w_\\-.]+/[\\w_\\-.:]+$", v)
+		match, _ := regexp.MatchString("^/[\\w_\\-.]+/"+f5NameValuePattern+"$", v)
 		if !match {
-			errors = append(errors, fmt.Errorf("%q must match /Partition/Name and contain letters, numbers or [._-:]. e.g. /Common/my-pool", field))
+			errors = append(errors, fmt.Errorf("%q must match /Partition/Name and contain letters, numbers or [._-:], e.g. /Common/my-pool, with an optional bracketed IPv6 literal and %%<route-domain> suffix, e.g. /Common/[2001:db8::1%%2]", field))
 		}
 	}
-	return
+	return applyValidatorMode("f5_name", errors)
 }
 
 func validateF5NameWithDirectory(value interface{}, field string) (ws []string, errors []error) {
@@ -86,12 +178,12 @@ func validateF5NameWithDirectory(value interface{}, field string) (ws []string,
 	}
 
 	for _, v := range values {
-		match, _ := regexp.MatchString("(^/[\\w_\\-.]+/[\\w_\\-.:]+/[\\w_\\-.:]+$)|(^/[\\w_\\-.]+/[\\w_\\-.:]+$)", v)
+		match, _ := regexp.MatchString("(^/[\\w_\\-.]+/"+f5NameValuePattern+"/"+f5NameValuePattern+"$)|(^/[\\w_\\-.]+/"+f5NameValuePattern+"$)", v)
 		if !match {
-			errors = append(errors, fmt.Errorf("%q must match /Partition/Name or /Partition/Directory/Name  e.g. /Common/my-node or /Common/test/my-node", field))
+			errors = append(errors, fmt.Errorf("%q must match /Partition/Name or /Partition/Directory/Name  e.g. /Common/my-node or /Common/test/my-node, with an optional bracketed IPv6 literal and %%<route-domain> suffix, e.g. /Common/[2001:db8::1%%2]", field))
 		}
 	}
-	return
+	return applyValidatorMode("f5_name_with_directory", errors)
 }
 
 func validatePartitionName(value interface{}, field string) (ws []string, errors []error) {
@@ -119,7 +211,7 @@ func validatePartitionName(value interface{}, field string) (ws []string, errors
 			errors = append(errors, fmt.Errorf("%q name should not start with `/`, e.g Common [or] test-partition are valid ", field))
 		}
 	}
-	return
+	return applyValidatorMode("partition_name", errors)
 }
 
 func validatePoolMemberName(value interface{}, field string) (ws []string, errors []error) {
@@ -142,19 +234,19 @@ func validatePoolMemberName(value interface{}, field string) (ws []string, error
 	}
 
 	for _, v := range values {
-		if strings.Count(v, ":") >= 2 {
-			match, _ := regexp.MatchString("^\\/[\\w_\\-.]+\\/[\\w_\\-.:]+.\\d+$", v)
+		if strings.HasPrefix(v, "/") {
+			match, _ := regexp.MatchString("^/[\\w_\\-.]+/"+hostPortPattern+"$", v)
 			if !match {
-				errors = append(errors, fmt.Errorf("%q must match /Partition/Node_Name:Port and contain letters, numbers or [:._-]. e.g. /Common/node1:80", field))
+				errors = append(errors, fmt.Errorf("%q must match /Partition/Node_Name:Port and contain letters, numbers or [:._-]. e.g. /Common/node1:80 or /Common/[2001:db8::1%%2]:80", field))
 			}
 		} else {
-			match, _ := regexp.MatchString("^[\\w_\\-.]+:\\d+$", v)
+			match, _ := regexp.MatchString("^"+hostPortPattern+"$", v)
 			if !match {
-				errors = append(errors, fmt.Errorf("%q must match Node-address:Port and Node Address is IP/FQDN. e.g. 1.1.1.1:80/www.google.com:80", field))
+				errors = append(errors, fmt.Errorf("%q must match Node-address:Port where Node-address is an IPv4/IPv6 address or FQDN. e.g. 1.1.1.1:80, [2001:db8::1]:80, or www.google.com:80", field))
 			}
 		}
 	}
-	return
+	return applyValidatorMode("pool_member_name", errors)
 }
 
 // IsValidIP tests that the argument is a valid IP address.
@@ -189,7 +281,7 @@ func validateEnabledDisabled(value interface{}, field string) (ws []string, erro
 			errors = append(errors, fmt.Errorf("%q must match as enabled or disabled", field))
 		}
 	}
-	return
+	return applyValidatorMode("enabled_disabled", errors)
 }
 
 func validateReqPrefDisabled(value interface{}, field string) (ws []string, errors []error) {
@@ -213,7 +305,7 @@ func validateReqPrefDisabled(value interface{}, field string) (ws []string, erro
 			errors = append(errors, fmt.Errorf("%q must match as required, preferred, or disabled", field))
 		}
 	}
-	return
+	return applyValidatorMode("req_pref_disabled", errors)
 }
 
 func validateDataGroupType(value interface{}, field string) (ws []string, errors []error) {
@@ -237,7 +329,7 @@ func validateDataGroupType(value interface{}, field string) (ws []string, errors
 			errors = append(errors, fmt.Errorf("%q must match as string, ip, or integer", field))
 		}
 	}
-	return
+	return applyValidatorMode("data_group_type", errors)
 }
 func validatePoolLicenseType(value interface{}, field string) (ws []string, errors []error) {
 	var values []string
@@ -259,7 +351,7 @@ func validatePoolLicenseType(value interface{}, field string) (ws []string, erro
 			errors = append(errors, fmt.Errorf("%q must match as Utility (or) Regkey", field))
 		}
 	}
-	return
+	return applyValidatorMode("pool_license_type", errors)
 }
 func validateAssignmentType(value interface{}, field string) (ws []string, errors []error) {
 	var values []string
@@ -281,7 +373,49 @@ func validateAssignmentType(value interface{}, field string) (ws []string, error
 			errors = append(errors, fmt.Errorf("%q must match as MANAGED/UNMANAGED/UNREACHABLE", field))
 		}
 	}
-	return
+	return applyValidatorMode("assignment_type", errors)
+}
+
+// validateDOJSON checks that a Declarative Onboarding config is valid JSON
+// and carries the minimum shape DO requires, in either of the two forms DO
+// itself accepts: a "DO"-class wrapper around a declaration block, or the
+// inner declaration (class "Device") posted directly with no wrapper.
+func validateDOJSON(value interface{}, field string) (ws []string, errors []error) {
+	v, ok := value.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("Unknown type %v in validateDOJSON", reflect.TypeOf(value)))
+		return applyValidatorMode("do_json", errors)
+	}
+
+	var decl map[string]interface{}
+	if err := json.Unmarshal([]byte(v), &decl); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be valid JSON: %s", field, err))
+		return applyValidatorMode("do_json", errors)
+	}
+
+	declaration := decl
+	switch class, _ := decl["class"].(string); class {
+	case "DO":
+		inner, ok := decl["declaration"].(map[string]interface{})
+		if !ok {
+			errors = append(errors, fmt.Errorf(`%q with "class": "DO" must contain a declaration block`, field))
+			return applyValidatorMode("do_json", errors)
+		}
+		declaration = inner
+	case "Device":
+		// The declaration was posted directly, without the "DO" wrapper.
+	default:
+		errors = append(errors, fmt.Errorf(`%q must set top-level "class" to "DO" or "Device"`, field))
+		return applyValidatorMode("do_json", errors)
+	}
+
+	if _, ok := declaration["schemaVersion"]; !ok {
+		errors = append(errors, fmt.Errorf("%q declaration must set a schemaVersion", field))
+	}
+	if _, ok := declaration["Common"]; !ok {
+		errors = append(errors, fmt.Errorf("%q declaration must contain a Common tenant block", field))
+	}
+	return applyValidatorMode("do_json", errors)
 }
 
 func getDeviceUri(str string) ([]string, error) {
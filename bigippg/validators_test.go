@@ -0,0 +1,139 @@
+package bigippg
+
+import "testing"
+
+func TestValidateF5Name(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"v4", "/Common/10.1.1.1", false},
+		{"v4 with route domain", "/Common/10.1.1.1%2", false},
+		{"v6", "/Common/2001:db8::1", false},
+		{"v6 bracketed with route domain", "/Common/[2001:db8::1%2]", false},
+		{"fqdn", "/Common/node1.example.com", false},
+		{"missing partition", "10.1.1.1", true},
+		{"invalid characters", "/Common/node@1", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := validateF5Name(tc.value, "name")
+			if tc.wantErr && len(errs) == 0 {
+				t.Errorf("validateF5Name(%q): expected an error, got none", tc.value)
+			}
+			if !tc.wantErr && len(errs) != 0 {
+				t.Errorf("validateF5Name(%q): expected no error, got %v", tc.value, errs)
+			}
+		})
+	}
+}
+
+func TestValidateF5NameWithDirectory(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"v4", "/Common/10.1.1.1", false},
+		{"v4 with route domain and directory", "/Common/test/10.1.1.1%2", false},
+		{"v6", "/Common/2001:db8::1", false},
+		{"v6 bracketed with route domain", "/Common/[2001:db8::1%2]", false},
+		{"fqdn with directory", "/Common/test/node1.example.com", false},
+		{"missing partition", "node1.example.com", true},
+		{"invalid characters", "/Common/node@1", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := validateF5NameWithDirectory(tc.value, "name")
+			if tc.wantErr && len(errs) == 0 {
+				t.Errorf("validateF5NameWithDirectory(%q): expected an error, got none", tc.value)
+			}
+			if !tc.wantErr && len(errs) != 0 {
+				t.Errorf("validateF5NameWithDirectory(%q): expected no error, got %v", tc.value, errs)
+			}
+		})
+	}
+}
+
+func TestValidatePoolMemberName(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"v4", "1.1.1.1:80", false},
+		{"v4 with route domain", "1.1.1.1%2:80", false},
+		{"v6", "[2001:db8::1]:80", false},
+		{"v6 with route domain", "[2001:db8::1%2]:80", false},
+		{"fqdn", "www.google.com:80", false},
+		{"partition-qualified v4", "/Common/node1:80", false},
+		{"partition-qualified v6 with route domain", "/Common/[2001:db8::1%2]:80", false},
+		{"missing port", "1.1.1.1", true},
+		{"unbracketed v6 with port", "2001:db8::1:80", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := validatePoolMemberName(tc.value, "name")
+			if tc.wantErr && len(errs) == 0 {
+				t.Errorf("validatePoolMemberName(%q): expected an error, got none", tc.value)
+			}
+			if !tc.wantErr && len(errs) != 0 {
+				t.Errorf("validatePoolMemberName(%q): expected no error, got %v", tc.value, errs)
+			}
+		})
+	}
+}
+
+func TestValidateDOJSON(t *testing.T) {
+	const wrapped = `{
+		"schemaVersion": "1.0.0",
+		"class": "DO",
+		"declaration": {
+			"schemaVersion": "1.0.0",
+			"class": "Device",
+			"Common": {
+				"class": "Tenant",
+				"hostname": "bigip1.example.com"
+			}
+		}
+	}`
+
+	const bare = `{
+		"schemaVersion": "1.0.0",
+		"class": "Device",
+		"Common": {
+			"class": "Tenant",
+			"hostname": "bigip1.example.com"
+		}
+	}`
+
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"DO-wrapped declaration", wrapped, false},
+		{"bare declaration", bare, false},
+		{"not JSON", "not json", true},
+		{"unknown class", `{"class": "AS3"}`, true},
+		{"DO wrapper missing declaration", `{"class": "DO"}`, true},
+		{"bare declaration missing schemaVersion", `{"class": "Device", "Common": {}}`, true},
+		{"bare declaration missing Common", `{"schemaVersion": "1.0.0", "class": "Device"}`, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, errs := validateDOJSON(tc.value, "config")
+			if tc.wantErr && len(errs) == 0 {
+				t.Errorf("validateDOJSON(%q): expected an error, got none", tc.value)
+			}
+			if !tc.wantErr && len(errs) != 0 {
+				t.Errorf("validateDOJSON(%q): expected no error, got %v", tc.value, errs)
+			}
+		})
+	}
+}